@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -22,9 +21,11 @@ var (
 	serverAddr = flag.String("addr", "127.0.0.1:50051", "The server address in the format of host:port")
 	producers  = flag.Int("p", 1, "Number of concurrent producer threads")
 	sourceDir  = flag.String("dir", "test_data", "Root directory for source videos")
-)
 
-const ChunkSize = 64 * 1024 // 64KB chunks
+	parallelism = flag.Int("parallelism", 4, "Number of concurrent chunk-upload workers per file")
+	chunkSize   = flag.Int64("chunk-size", 1<<20, "Size in bytes of each uploaded chunk")
+	progressFmt = flag.String("progress", "bar", "Progress reporting: bar, json, or none")
+)
 
 const SampleVideoURL = "https://test-videos.co.uk/vids/bigbuckbunny/mp4/h264/360/Big_Buck_Bunny_360_10s_1MB.mp4"
 
@@ -77,59 +78,6 @@ func runProducer(id int, wg *sync.WaitGroup) {
 	}
 }
 
-func uploadFile(client pb.MediaUploadClient, producerID int, path string) {
-	file, err := os.Open(path)
-	if err != nil {
-		slog.Error("Failed to open file", "error", err)
-		return
-	}
-	defer file.Close()
-
-	// Context Timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	stream, err := client.UploadVideo(ctx)
-	if err != nil {
-		slog.Error("Failed to create stream", "error", err)
-		return
-	}
-
-	// 5. Stream the chunks
-	buf := make([]byte, ChunkSize)
-	slog.Info("Starting Upload", "producer", producerID, "file", path)
-
-	for {
-		n, err := file.Read(buf)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			slog.Error("File read error", "error", err)
-			return
-		}
-
-		req := &pb.VideoChunk{
-			Filename: filepath.Base(path),
-			Content:  buf[:n],
-		}
-
-		if err := stream.Send(req); err != nil {
-			slog.Error("Send failed (Server likely busy)", "producer", producerID, "error", err)
-			return
-		}
-	}
-
-	// 6. Close and Receive Response
-	res, err := stream.CloseAndRecv()
-	if err != nil {
-		slog.Error("Upload failed", "producer", producerID, "error", err)
-		return
-	}
-
-	slog.Info("Upload Success", "producer", producerID, "server_msg", res.Message, "video_id", res.VideoId)
-}
-
 func setupDummyData(root string, count int) error {
 	if _, err := os.Stat(root); os.IsNotExist(err) {
 		os.Mkdir(root, 0755)
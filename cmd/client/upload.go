@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/Perdit-io/media-upload/pb"
+)
+
+// byteRange is a contiguous, half-open span of a file to upload.
+type byteRange struct {
+	Start int64
+	End   int64
+}
+
+// splitRanges divides a file of the given size into up to n contiguous
+// ranges, each at least chunkSize long except the last, which ends at size
+// and may be shorter (fewer, larger ranges are produced when the file is
+// too small to give every worker a full chunk).
+func splitRanges(size int64, n int, chunkSize int64) []byteRange {
+	if n < 1 {
+		n = 1
+	}
+
+	rangeSize := (size + int64(n) - 1) / int64(n)
+	if rangeSize < chunkSize {
+		rangeSize = chunkSize
+	}
+
+	var ranges []byteRange
+	for start := int64(0); start < size; start += rangeSize {
+		end := start + rangeSize
+		if end > size {
+			end = size
+		}
+		ranges = append(ranges, byteRange{Start: start, End: end})
+	}
+	return ranges
+}
+
+// sha256File returns the hex-encoded SHA-256 of f's full contents, leaving
+// the file positioned at the start for the upload that follows.
+func sha256File(f *os.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("checksum file: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// uploadFile uploads path via the resumable Init/Chunk/Complete RPCs,
+// splitting it into contiguous ranges so *parallelism workers can push
+// chunks concurrently.
+func uploadFile(client pb.MediaUploadClient, producerID int, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		slog.Error("Failed to open file", "error", err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		slog.Error("Failed to stat file", "error", err)
+		return
+	}
+	size := info.Size()
+
+	sum, err := sha256File(file)
+	if err != nil {
+		slog.Error("Failed to checksum file", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	init, err := client.InitUpload(ctx, &pb.InitUploadRequest{
+		Filename:  filepath.Base(path),
+		TotalSize: size,
+		Sha256:    sum,
+	})
+	if err != nil {
+		slog.Error("Failed to init upload", "producer", producerID, "error", err)
+		return
+	}
+
+	slog.Info("Starting Upload", "producer", producerID, "file", path, "upload_id", init.UploadId)
+
+	progress := newProgressReporter(*progressFmt, path, size)
+	defer progress.Stop()
+
+	ranges := splitRanges(size, *parallelism, *chunkSize)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(ranges))
+	for _, rng := range ranges {
+		wg.Add(1)
+		go func(rng byteRange) {
+			defer wg.Done()
+			if err := uploadRange(ctx, client, file, init.UploadId, rng, *chunkSize, progress); err != nil {
+				errCh <- err
+			}
+		}(rng)
+	}
+	wg.Wait()
+	close(errCh)
+
+	if err := <-errCh; err != nil {
+		slog.Error("Upload failed", "producer", producerID, "error", err)
+		return
+	}
+
+	res, err := client.CompleteUpload(ctx, &pb.CompleteUploadRequest{UploadId: init.UploadId})
+	if err != nil {
+		slog.Error("Failed to complete upload", "producer", producerID, "error", err)
+		return
+	}
+
+	progress.Done()
+	slog.Info("Upload Success", "producer", producerID, "server_msg", res.Message, "video_id", res.VideoId)
+}
+
+// uploadRange pushes rng in chunkSize-sized pieces, reading via ReadAt so it
+// can run concurrently with other ranges against the same file handle.
+func uploadRange(ctx context.Context, client pb.MediaUploadClient, file *os.File, uploadID string, rng byteRange, chunkSize int64, progress *progressReporter) error {
+	buf := make([]byte, chunkSize)
+
+	for offset := rng.Start; offset < rng.End; {
+		n := chunkSize
+		if offset+n > rng.End {
+			n = rng.End - offset
+		}
+
+		if _, err := file.ReadAt(buf[:n], offset); err != nil && err != io.EOF {
+			return fmt.Errorf("read range: %w", err)
+		}
+
+		_, err := client.UploadChunk(ctx, &pb.UploadChunkRequest{
+			UploadId: uploadID,
+			Offset:   offset,
+			Data:     buf[:n],
+		})
+		if err != nil {
+			return fmt.Errorf("send chunk at offset %d: %w", offset, err)
+		}
+
+		progress.Add(n)
+		offset += n
+	}
+	return nil
+}
+
+// progressReporter aggregates bytes sent across workers and renders
+// periodic updates in one of three formats: bar, json, or none.
+type progressReporter struct {
+	format string
+	path   string
+	total  int64
+	sent   atomic.Int64
+
+	ticker *time.Ticker
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newProgressReporter(format, path string, total int64) *progressReporter {
+	p := &progressReporter{
+		format: format,
+		path:   path,
+		total:  total,
+		done:   make(chan struct{}),
+	}
+
+	if format == "none" {
+		return p
+	}
+
+	p.ticker = time.NewTicker(200 * time.Millisecond)
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *progressReporter) Add(n int64) {
+	p.sent.Add(n)
+}
+
+func (p *progressReporter) run() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.ticker.C:
+			p.render()
+		case <-p.done:
+			p.render()
+			return
+		}
+	}
+}
+
+func (p *progressReporter) render() {
+	sent := p.sent.Load()
+	pct := float64(100)
+	if p.total > 0 {
+		pct = float64(sent) / float64(p.total) * 100
+	}
+
+	switch p.format {
+	case "json":
+		b, _ := json.Marshal(map[string]any{
+			"file":    p.path,
+			"sent":    sent,
+			"total":   p.total,
+			"percent": pct,
+		})
+		fmt.Println(string(b))
+	case "bar":
+		const width = 30
+		filled := int(pct / 100 * width)
+		if filled > width {
+			filled = width
+		}
+		bar := ""
+		for i := 0; i < width; i++ {
+			if i < filled {
+				bar += "="
+			} else {
+				bar += " "
+			}
+		}
+		fmt.Printf("\r%s [%s] %5.1f%%", filepath.Base(p.path), bar, pct)
+	}
+}
+
+// Done stops the reporter, which renders one last time, and then prints a
+// final newline so a "bar" render doesn't clobber the next log line.
+func (p *progressReporter) Done() {
+	p.Stop()
+	if p.format == "bar" {
+		fmt.Println()
+	}
+}
+
+// Stop halts the background ticker goroutine. Safe to call even if the
+// reporter was created with format "none", and safe to call more than once.
+func (p *progressReporter) Stop() {
+	if p.ticker == nil {
+		return
+	}
+	close(p.done)
+	p.ticker.Stop()
+	p.wg.Wait()
+	p.ticker = nil
+}
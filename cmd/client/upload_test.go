@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestSplitRangesCoversWholeFileWithNoGapsOrOverlap(t *testing.T) {
+	ranges := splitRanges(1000, 4, 64)
+
+	var covered int64
+	for i, r := range ranges {
+		if r.Start != covered {
+			t.Fatalf("range %d starts at %d, expected %d (gap or overlap)", i, r.Start, covered)
+		}
+		covered = r.End
+	}
+	if covered != 1000 {
+		t.Errorf("ranges cover up to %d, expected 1000", covered)
+	}
+}
+
+func TestSplitRangesRespectsMinimumChunkSize(t *testing.T) {
+	// A 100-byte file split 10 ways would give 10-byte ranges; chunkSize
+	// should win so workers aren't handed slivers smaller than a chunk.
+	ranges := splitRanges(100, 10, 64)
+
+	for i, r := range ranges {
+		if size := r.End - r.Start; size < 64 && r.End != 100 {
+			t.Errorf("range %d is %d bytes, smaller than chunkSize and not the final range", i, size)
+		}
+	}
+}
+
+func TestSplitRangesSingleWorker(t *testing.T) {
+	ranges := splitRanges(500, 1, 64)
+
+	if len(ranges) != 1 {
+		t.Fatalf("expected a single range for n=1, got %d", len(ranges))
+	}
+	if ranges[0] != (byteRange{Start: 0, End: 500}) {
+		t.Errorf("expected {0 500}, got %v", ranges[0])
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,51 +11,117 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/Perdit-io/media-upload/internal/server"
+	"github.com/Perdit-io/media-upload/internal/storage"
 	pb "github.com/Perdit-io/media-upload/pb"
 )
 
+// upgrader upgrades /api/jobs/stream to a WebSocket. Origin checks are left
+// wide open since the GUI is served from the same process for now.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
 var (
 	port     = flag.Int("port", 50051, "gRPC server port")
 	httpPort = flag.Int("http", 8080, "HTTP server port")
 	workers  = flag.Int("c", 2, "Number of consumer worker threads")
 	queueLen = flag.Int("q", 5, "Max queue length (leaky bucket)")
 
+	storageKind = flag.String("storage", "local", "Storage backend: local or s3")
+	s3Bucket    = flag.String("s3-bucket", "", "S3 bucket name (storage=s3)")
+	s3Region    = flag.String("s3-region", "us-east-1", "S3 region (storage=s3)")
+	s3Endpoint  = flag.String("s3-endpoint", "", "S3 endpoint override, e.g. for MinIO (storage=s3)")
+
+	queueKind   = flag.String("queue", "mem", "Job queue backend: mem or postgres")
+	dbDSN       = flag.String("db-dsn", "", "Postgres DSN (queue=postgres)")
+	maxAttempts = flag.Int("max-attempts", 3, "Max ffmpeg attempts before a job is marked Failed")
+
+	maxDurationMinutes = flag.Int("max-duration-minutes", 30, "Reject sources longer than this many minutes (0 disables the check)")
+	allowedCodecs      = flag.String("allowed-codecs", "h264,hevc,vp9", "Comma-separated allowed source video codecs (empty allows any)")
+	ffmpegConcurrency  = flag.Int("ffmpeg-concurrency", 0, "Max simultaneous ffmpeg processes across all workers (0 = runtime.NumCPU())")
+
 	globalWP *server.WorkerPool
 )
 
+// newPolicy builds the server.Policy from flags.
+func newPolicy() server.Policy {
+	var codecs []string
+	if *allowedCodecs != "" {
+		codecs = strings.Split(*allowedCodecs, ",")
+	}
+
+	return server.Policy{
+		MaxDuration:   time.Duration(*maxDurationMinutes) * time.Minute,
+		AllowedCodecs: codecs,
+	}
+}
+
+// newQueue builds the configured server.Queue.
+func newQueue(ctx context.Context) (server.Queue, error) {
+	switch *queueKind {
+	case "postgres":
+		return server.NewPgQueue(ctx, *dbDSN, *queueLen)
+	case "mem":
+		return server.NewMemQueue(*queueLen), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q", *queueKind)
+	}
+}
+
+// newStorageBackend builds the configured storage.Backend.
+func newStorageBackend(ctx context.Context) (storage.Backend, error) {
+	switch *storageKind {
+	case "s3":
+		return storage.NewS3(ctx, *s3Bucket, *s3Region, *s3Endpoint)
+	case "local":
+		return storage.NewLocal("uploads"), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", *storageKind)
+	}
+}
+
 type grpcServer struct {
 	pb.UnimplementedMediaUploadServer
 	wp *server.WorkerPool
 }
 
 func (s *grpcServer) UploadVideo(stream pb.MediaUpload_UploadVideoServer) error {
-	var file *os.File
+	var pw *io.PipeWriter
 	var videoID string
 	var fileSize int
+	saveErrCh := make(chan error, 1)
 
 	for {
 		req, err := stream.Recv()
 
 		// 1. End of Stream (Upload Complete)
 		if err == io.EOF {
-			if file != nil {
-				file.Close()
+			if pw != nil {
+				pw.Close()
+				if err := <-saveErrCh; err != nil {
+					return status.Errorf(codes.Internal, "Failed to store upload: %v", err)
+				}
 			}
 
 			// Try to Enqueue
-			job := server.Job{Filename: file.Name(), VideoID: videoID}
+			job := server.Job{VideoID: videoID}
 			added := s.wp.AddJob(job)
 
 			if !added {
 				slog.Warn("Queue full, dropping video", "id", videoID)
-				os.Remove(job.Filename) // Cleanup dropped file
+				if err := s.wp.Storage.Delete(stream.Context(), server.SourceKey(videoID)); err != nil {
+					slog.Error("Failed to clean up dropped upload", "id", videoID, "error", err)
+				}
 				return status.Errorf(codes.ResourceExhausted, "Server busy, queue full")
 			}
 
@@ -67,31 +134,46 @@ func (s *grpcServer) UploadVideo(stream pb.MediaUpload_UploadVideoServer) error
 			})
 		}
 		if err != nil {
+			abortUpload(pw, saveErrCh, err)
 			return status.Errorf(codes.Unknown, "Stream error: %v", err)
 		}
 
-		// 2. First Chunk (Create File)
-		if file == nil {
+		// 2. First Chunk (Start Streaming to the Storage Backend)
+		if pw == nil {
 			videoID = fmt.Sprintf("video_%s", filepath.Base(req.Filename))
 			videoID = filepath.Base(videoID)
-			savePath := filepath.Join("uploads", videoID)
 
-			file, err = os.Create(savePath)
-			if err != nil {
-				return status.Errorf(codes.Internal, "Failed to create file: %v", err)
-			}
-			defer file.Close()
+			var pr *io.PipeReader
+			pr, pw = io.Pipe()
+			go func() {
+				saveErrCh <- s.wp.Storage.Save(stream.Context(), server.SourceKey(videoID), pr)
+			}()
 		}
 
 		// 3. Write Chunk
-		n, err := file.Write(req.Content)
+		n, err := pw.Write(req.Content)
 		if err != nil {
+			abortUpload(pw, saveErrCh, err)
 			return status.Errorf(codes.Internal, "Write error: %v", err)
 		}
 		fileSize += n
 	}
 }
 
+// abortUpload unblocks and waits for the goroutine streaming pw's paired
+// PipeReader into the storage backend. io.Pipe reads never observe context
+// cancellation, only Write/Close/CloseWithError on the writer, so without
+// this an aborted upload (client disconnect, canceled context, a network
+// blip - all routine mid-stream) leaks that goroutine and its open file
+// handle or in-flight multipart session forever.
+func abortUpload(pw *io.PipeWriter, saveErrCh chan error, cause error) {
+	if pw == nil {
+		return
+	}
+	pw.CloseWithError(cause)
+	<-saveErrCh
+}
+
 func main() {
 	flag.Parse()
 
@@ -99,20 +181,46 @@ func main() {
 		os.Mkdir("uploads", 0755)
 	}
 
+	backend, err := newStorageBackend(context.Background())
+	if err != nil {
+		slog.Error("Failed to initialize storage backend", "error", err)
+		os.Exit(1)
+	}
+
+	queue, err := newQueue(context.Background())
+	if err != nil {
+		slog.Error("Failed to initialize job queue", "error", err)
+		os.Exit(1)
+	}
+
+	globalResumable, err = server.NewResumableManager(filepath.Join("uploads", ".resumable"))
+	if err != nil {
+		slog.Error("Failed to initialize resumable upload manager", "error", err)
+		os.Exit(1)
+	}
+
 	// 1. Start Worker Pool
-	globalWP = server.NewWorkerPool(*workers, *queueLen)
+	globalWP = server.NewWorkerPool(*workers, queue, backend, *maxAttempts, newPolicy(), *ffmpegConcurrency)
 	globalWP.Start()
 	defer globalWP.Stop()
 
 	// 2. Start HTTP Server (GUI)
 	fs := http.FileServer(http.Dir("./static"))
 	http.Handle("/", fs)
-	// Serve uploaded videos/thumbnails
-	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	// Serve uploaded videos and their HLS assets, proxying or redirecting through the backend
+	http.HandleFunc("/uploads/", handleUploads)
 	// API Endpoint: Returns JSON list of videos
 	http.HandleFunc("/api/videos", handleListVideos)
 	// Web Upload Endpoint
 	http.HandleFunc("/api/upload", handleWebUpload)
+	// Job Status API: single snapshot, or a live WebSocket stream of transitions
+	http.HandleFunc("GET /api/jobs/{id}", handleJobStatus)
+	http.HandleFunc("GET /api/jobs/stream", handleJobStream)
+	// Chunk-resumable Upload API: init/chunk/complete, plus a resume query
+	http.HandleFunc("POST /api/upload/init", handleUploadInit)
+	http.HandleFunc("POST /api/upload/chunk", handleUploadChunk)
+	http.HandleFunc("POST /api/upload/complete", handleUploadComplete)
+	http.HandleFunc("GET /api/upload/{id}", handleUploadStatus)
 
 	go func() {
 		slog.Info("HTTP GUI Running", "url", fmt.Sprintf("http://localhost:%d", *httpPort))
@@ -137,20 +245,72 @@ func main() {
 	}
 }
 
-// Scans the upload folder and returns a list of video files
+// Serves an uploaded video or derived asset through the storage backend:
+// redirects to a presigned URL for backends that support it (S3), otherwise
+// proxies the bytes straight through.
+func handleUploads(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/uploads/")
+
+	if rb, ok := globalWP.Storage.(storage.Redirector); ok {
+		url, err := rb.URL(r.Context(), key, 15*time.Minute)
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+
+	obj, err := globalWP.Storage.Open(r.Context(), key)
+	if err != nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	defer obj.Close()
+
+	io.Copy(w, obj)
+}
+
+// videoEntry describes one finished upload in the /api/videos response.
+type videoEntry struct {
+	ID          string `json:"id"`
+	PlaylistURL string `json:"playlist_url"`
+}
+
+// Lists every uploaded video that has finished transcoding, pointing the
+// browser at each one's HLS master playlist. Uploads now live under a
+// per-video directory (see SourceKey and generateHLSLadder) rather than as
+// a flat <videoID>.mp4 file, so this enumerates storage keys through the
+// backend instead of assuming a local .mp4 on disk.
 func handleListVideos(w http.ResponseWriter, r *http.Request) {
-	entries, err := os.ReadDir("uploads")
+	lister, ok := globalWP.Storage.(storage.Lister)
+	if !ok {
+		http.Error(w, "Storage backend does not support listing", http.StatusNotImplemented)
+		return
+	}
+
+	ids, err := lister.List(r.Context(), "")
 	if err != nil {
-		http.Error(w, "Failed to read uploads", http.StatusInternalServerError)
+		http.Error(w, "Failed to list uploads", http.StatusInternalServerError)
 		return
 	}
 
-	var videos []string
-	for _, e := range entries {
-		// Only send back .mp4 files (we infer the preview GIF path in JS)
-		if !e.IsDir() && strings.HasSuffix(e.Name(), ".mp4") {
-			videos = append(videos, e.Name())
+	var videos []videoEntry
+	for _, id := range ids {
+		children, err := lister.List(r.Context(), id)
+		if err != nil {
+			continue
 		}
+		// Anything without a master playlist yet is still processing,
+		// failed, or isn't a video directory at all (e.g. the resumable
+		// upload manager's own metadata directory) - skip it.
+		if !slices.Contains(children, "master.m3u8") {
+			continue
+		}
+		videos = append(videos, videoEntry{
+			ID:          id,
+			PlaylistURL: fmt.Sprintf("/uploads/%s/master.m3u8", id),
+		})
 	}
 
 	// Respond with JSON
@@ -175,36 +335,67 @@ func handleWebUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// 2. Save File
+	// 2. Save File (streamed straight to the storage backend)
 	videoID := fmt.Sprintf("video_web_%s", filepath.Base(header.Filename))
-	savePath := filepath.Join("uploads", videoID)
 
-	dst, err := os.Create(savePath)
-	if err != nil {
-		http.Error(w, "Failed to create file", http.StatusInternalServerError)
-		return
-	}
-
-	// Copy file content
-	_, err = io.Copy(dst, file)
-	dst.Close() // Close immediately so Worker can open it
-	if err != nil {
+	if err := globalWP.Storage.Save(r.Context(), server.SourceKey(videoID), file); err != nil {
 		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
 
 	// 3. Send to Worker Pool (Leaky Bucket Check)
-	job := server.Job{Filename: savePath, VideoID: videoID}
+	job := server.Job{VideoID: videoID}
 	added := globalWP.AddJob(job)
 
 	if !added {
 		slog.Warn("Web upload dropped (Queue Full)", "id", videoID)
-		os.Remove(savePath)
+		if err := globalWP.Storage.Delete(r.Context(), server.SourceKey(videoID)); err != nil {
+			slog.Error("Failed to clean up dropped upload", "id", videoID, "error", err)
+		}
 		http.Error(w, "Server busy (Queue Full)", http.StatusServiceUnavailable)
 		return
 	}
 
 	slog.Info("Web Upload Queued", "id", videoID)
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Upload successful"))
+
+	// Return the job ID immediately so the upload page can correlate this
+	// request with status updates from /api/jobs/{id} or /api/jobs/stream.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Upload successful",
+		"job_id":   videoID,
+		"video_id": videoID,
+	})
+}
+
+// Returns the current status of a single job.
+func handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	status, ok := globalWP.Tracker.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Unknown job", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Upgrades to a WebSocket and pushes every job status transition as it
+// happens, so the upload page can show live progress instead of polling.
+func handleJobStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("WebSocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	updates, cancel := globalWP.Tracker.Subscribe()
+	defer cancel()
+
+	for status := range updates {
+		if err := conn.WriteJSON(status); err != nil {
+			return
+		}
+	}
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Perdit-io/media-upload/internal/server"
+	pb "github.com/Perdit-io/media-upload/pb"
+)
+
+// globalResumable tracks in-progress chunk-resumable uploads, shared by the
+// gRPC and HTTP resumable upload endpoints.
+var globalResumable *server.ResumableManager
+
+// completeUpload validates and stores an assembled upload, then enqueues
+// the ffmpeg job. Shared by the gRPC and HTTP CompleteUpload handlers.
+func completeUpload(ctx context.Context, uploadID string) (string, error) {
+	sess, f, err := globalResumable.Complete(ctx, uploadID)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	videoID := fmt.Sprintf("video_%s", filepath.Base(sess.Filename))
+	if err := globalWP.Storage.Save(ctx, server.SourceKey(videoID), f); err != nil {
+		return "", fmt.Errorf("store assembled upload: %w", err)
+	}
+	globalResumable.Cleanup(uploadID)
+
+	if added := globalWP.AddJob(server.Job{VideoID: videoID}); !added {
+		return "", fmt.Errorf("queue full")
+	}
+	return videoID, nil
+}
+
+// InitUpload begins a resumable upload and hands back an upload_id.
+func (s *grpcServer) InitUpload(ctx context.Context, req *pb.InitUploadRequest) (*pb.InitUploadResponse, error) {
+	uploadID, err := globalResumable.Init(req.Filename, req.TotalSize, req.Sha256)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to init upload: %v", err)
+	}
+	return &pb.InitUploadResponse{UploadId: uploadID}, nil
+}
+
+// UploadChunk writes a chunk at a specific offset. Resending an already
+// received offset/data pair is a no-op.
+func (s *grpcServer) UploadChunk(ctx context.Context, req *pb.UploadChunkRequest) (*pb.UploadChunkResponse, error) {
+	if err := globalResumable.WriteChunk(req.UploadId, req.Offset, req.Data); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to write chunk: %v", err)
+	}
+	return &pb.UploadChunkResponse{Success: true}, nil
+}
+
+// CompleteUpload validates the full checksum and enqueues the ffmpeg job.
+func (s *grpcServer) CompleteUpload(ctx context.Context, req *pb.CompleteUploadRequest) (*pb.UploadStatus, error) {
+	videoID, err := completeUpload(ctx, req.UploadId)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "Failed to complete upload: %v", err)
+	}
+
+	slog.Info("Resumable Upload Queued", "id", videoID)
+	return &pb.UploadStatus{Message: "Upload successful", Success: true, VideoId: videoID}, nil
+}
+
+// POST /api/upload/init {filename, total_size, sha256} -> {upload_id}
+func handleUploadInit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filename  string `json:"filename"`
+		TotalSize int64  `json:"total_size"`
+		SHA256    string `json:"sha256"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	uploadID, err := globalResumable.Init(req.Filename, req.TotalSize, req.SHA256)
+	if err != nil {
+		http.Error(w, "Failed to init upload", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"upload_id": uploadID})
+}
+
+// POST /api/upload/chunk, upload-id and offset headers, raw body is the chunk.
+func handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	uploadID := r.Header.Get("upload-id")
+	offset, err := strconv.ParseInt(r.Header.Get("offset"), 10, 64)
+	if uploadID == "" || err != nil {
+		http.Error(w, "Missing or invalid upload-id/offset header", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read chunk", http.StatusBadRequest)
+		return
+	}
+
+	if err := globalResumable.WriteChunk(uploadID, offset, data); err != nil {
+		http.Error(w, "Failed to write chunk", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// POST /api/upload/complete, upload-id header.
+func handleUploadComplete(w http.ResponseWriter, r *http.Request) {
+	uploadID := r.Header.Get("upload-id")
+	if uploadID == "" {
+		http.Error(w, "Missing upload-id header", http.StatusBadRequest)
+		return
+	}
+
+	videoID, err := completeUpload(r.Context(), uploadID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to complete upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	slog.Info("Resumable Upload Queued", "id", videoID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":  "Upload successful",
+		"job_id":   videoID,
+		"video_id": videoID,
+	})
+}
+
+// GET /api/upload/{id}, returns the session so clients can resume after a
+// disconnect or server restart.
+func handleUploadStatus(w http.ResponseWriter, r *http.Request) {
+	sess, err := globalResumable.Status(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Unknown upload", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Rung describes one variant in the adaptive HLS ladder.
+type Rung struct {
+	Height  int
+	Bitrate string // e.g. "800k", passed to ffmpeg's -b:v
+}
+
+// hlsLadder is the set of variants generated for every upload; a rung is
+// skipped if it would upscale past the source resolution.
+var hlsLadder = []Rung{
+	{Height: 240, Bitrate: "400k"},
+	{Height: 480, Bitrate: "1400k"},
+	{Height: 720, Bitrate: "2800k"},
+}
+
+// generateHLSLadder transcodes inputFile into an adaptive HLS ladder and
+// stores every segment, variant playlist and the master playlist under
+// videoID in the storage backend.
+func (wp *WorkerPool) generateHLSLadder(ctx context.Context, inputFile, videoID string, info *MediaInfo) error {
+	rungs := selectRungs(hlsLadder, info.Height)
+	if len(rungs) == 0 {
+		return fmt.Errorf("no HLS rung at or below source height %dp", info.Height)
+	}
+
+	workDir, err := os.MkdirTemp("", "hls-*")
+	if err != nil {
+		return fmt.Errorf("create hls workdir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// Render every rung concurrently - runHLSRung's ffmpegSem is what bounds
+	// how many of these (across every job in the pool) actually run at
+	// once, separate from WorkerCount.
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(rungs))
+	for _, rung := range rungs {
+		wg.Add(1)
+		go func(rung Rung) {
+			defer wg.Done()
+			if err := wp.renderRung(ctx, inputFile, workDir, videoID, rung); err != nil {
+				errCh <- fmt.Errorf("%dp rung: %w", rung.Height, err)
+			}
+		}(rung)
+	}
+	wg.Wait()
+	close(errCh)
+
+	if err := <-errCh; err != nil {
+		return err
+	}
+
+	master := buildMasterPlaylist(info, rungs)
+	return wp.Storage.Save(ctx, fmt.Sprintf("%s/master.m3u8", videoID), strings.NewReader(master))
+}
+
+// renderRung transcodes a single rung into its own subdirectory of workDir
+// and uploads its segments and variant playlist to the storage backend.
+func (wp *WorkerPool) renderRung(ctx context.Context, inputFile, workDir, videoID string, rung Rung) error {
+	rungDir := filepath.Join(workDir, fmt.Sprintf("%dp", rung.Height))
+	if err := os.MkdirAll(rungDir, 0755); err != nil {
+		return fmt.Errorf("create rung dir: %w", err)
+	}
+
+	if err := wp.runHLSRung(ctx, inputFile, rungDir, rung); err != nil {
+		return err
+	}
+
+	return wp.uploadDir(ctx, rungDir, fmt.Sprintf("%s/%dp", videoID, rung.Height))
+}
+
+// selectRungs returns the ladder rungs that don't upscale past sourceHeight.
+func selectRungs(ladder []Rung, sourceHeight int) []Rung {
+	var selected []Rung
+	for _, rung := range ladder {
+		if rung.Height > sourceHeight {
+			continue
+		}
+		selected = append(selected, rung)
+	}
+	return selected
+}
+
+// runHLSRung spawns the ffmpeg process for a single rung, gated by the
+// worker pool's ffmpeg concurrency semaphore since one job now spawns
+// several ffmpeg processes.
+func (wp *WorkerPool) runHLSRung(ctx context.Context, inputFile, rungDir string, rung Rung) error {
+	wp.ffmpegSem <- struct{}{}
+	defer func() { <-wp.ffmpegSem }()
+
+	playlist := filepath.Join(rungDir, "index.m3u8")
+	segmentPattern := filepath.Join(rungDir, "segment_%03d.ts")
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y", "-i", inputFile,
+		"-vf", fmt.Sprintf("scale=-2:%d", rung.Height),
+		"-c:v", "libx264", "-b:v", rung.Bitrate,
+		"-hls_time", "4", "-hls_playlist_type", "vod",
+		"-hls_segment_filename", segmentPattern,
+		playlist,
+	)
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	return cmd.Run()
+}
+
+// uploadDir saves every file directly under dir into the storage backend,
+// keyed by keyPrefix/filename.
+func (wp *WorkerPool) uploadDir(ctx context.Context, dir, keyPrefix string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		err = wp.Storage.Save(ctx, fmt.Sprintf("%s/%s", keyPrefix, e.Name()), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildMasterPlaylist writes an HLS master playlist referencing each
+// variant's own playlist, with a RESOLUTION derived from the source aspect
+// ratio.
+func buildMasterPlaylist(info *MediaInfo, variants []Rung) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+
+	for _, v := range variants {
+		width := v.Height
+		if info.Height > 0 {
+			width = v.Height * info.Width / info.Height
+		}
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", bitrateToBandwidth(v.Bitrate), width, v.Height)
+		fmt.Fprintf(&b, "%dp/index.m3u8\n", v.Height)
+	}
+
+	return b.String()
+}
+
+func bitrateToBandwidth(bitrate string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(bitrate, "k"))
+	return n * 1000
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectRungsDropsAboveSourceHeight(t *testing.T) {
+	got := selectRungs(hlsLadder, 480)
+	want := []Rung{
+		{Height: 240, Bitrate: "400k"},
+		{Height: 480, Bitrate: "1400k"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectRungs(hlsLadder, 480) = %v, want %v", got, want)
+	}
+}
+
+func TestSelectRungsKeepsExactSourceHeight(t *testing.T) {
+	got := selectRungs(hlsLadder, 240)
+	want := []Rung{{Height: 240, Bitrate: "400k"}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selectRungs(hlsLadder, 240) = %v, want %v", got, want)
+	}
+}
+
+func TestSelectRungsEmptyBelowSmallestRung(t *testing.T) {
+	got := selectRungs(hlsLadder, 144)
+	if len(got) != 0 {
+		t.Errorf("expected no rungs below the smallest rung height, got %v", got)
+	}
+}
+
+func TestBitrateToBandwidth(t *testing.T) {
+	if got := bitrateToBandwidth("1400k"); got != 1400000 {
+		t.Errorf("bitrateToBandwidth(\"1400k\") = %d, want 1400000", got)
+	}
+}
+
+func TestBuildMasterPlaylistScalesWidthToSourceAspectRatio(t *testing.T) {
+	info := &MediaInfo{Width: 1920, Height: 1080}
+	variants := []Rung{{Height: 720, Bitrate: "2800k"}}
+
+	playlist := buildMasterPlaylist(info, variants)
+
+	want := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720\n720p/index.m3u8\n"
+	if playlist != want {
+		t.Errorf("buildMasterPlaylist = %q, want %q", playlist, want)
+	}
+}
+
+func TestBuildMasterPlaylistListsEveryVariant(t *testing.T) {
+	info := &MediaInfo{Width: 1280, Height: 720}
+	variants := []Rung{
+		{Height: 240, Bitrate: "400k"},
+		{Height: 480, Bitrate: "1400k"},
+	}
+
+	playlist := buildMasterPlaylist(info, variants)
+
+	for _, want := range []string{"240p/index.m3u8", "480p/index.m3u8"} {
+		if !strings.Contains(playlist, want) {
+			t.Errorf("buildMasterPlaylist missing entry %q, got %q", want, playlist)
+		}
+	}
+}
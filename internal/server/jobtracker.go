@@ -0,0 +1,163 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTrackedJobs bounds JobTracker.jobs. Past this many entries, Track
+// prunes the oldest finished (Done/Failed) jobs to make room, so a
+// long-running server's memory use doesn't grow without bound with the
+// total number of uploads ever processed.
+const maxTrackedJobs = 10000
+
+// JobState is the lifecycle stage of a processing job.
+type JobState string
+
+const (
+	JobQueued     JobState = "queued"
+	JobProcessing JobState = "processing"
+	JobDone       JobState = "done"
+	JobFailed     JobState = "failed"
+)
+
+// JobStatus is a point-in-time snapshot of a job's lifecycle, keyed by VideoID.
+type JobStatus struct {
+	VideoID    string    `json:"video_id"`
+	State      JobState  `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	QueuedAt   time.Time `json:"queued_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// JobTracker records per-job lifecycle state and fans out transitions to
+// subscribers, such as the WebSocket progress stream.
+type JobTracker struct {
+	mu   sync.RWMutex
+	jobs map[string]*JobStatus
+
+	subMu sync.Mutex
+	subs  map[chan JobStatus]struct{}
+}
+
+func NewJobTracker() *JobTracker {
+	return &JobTracker{
+		jobs: make(map[string]*JobStatus),
+		subs: make(map[chan JobStatus]struct{}),
+	}
+}
+
+// Track registers a new job as Queued.
+func (t *JobTracker) Track(videoID string) {
+	t.mu.Lock()
+	if len(t.jobs) >= maxTrackedJobs {
+		t.pruneOldestFinishedLocked()
+	}
+	s := &JobStatus{VideoID: videoID, State: JobQueued, QueuedAt: time.Now()}
+	t.jobs[videoID] = s
+	snapshot := *s
+	t.mu.Unlock()
+	t.broadcast(snapshot)
+}
+
+// pruneOldestFinishedLocked evicts the oldest Done/Failed jobs until jobs
+// is back under maxTrackedJobs, or there's nothing left safe to evict.
+// Queued/Processing jobs are never evicted: they're still in flight, and
+// losing their entry would make AddJob's tracking lie about them.
+// Must be called with mu held.
+func (t *JobTracker) pruneOldestFinishedLocked() {
+	var finished []*JobStatus
+	for _, s := range t.jobs {
+		if s.State == JobDone || s.State == JobFailed {
+			finished = append(finished, s)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.Before(finished[j].FinishedAt) })
+
+	toEvict := len(t.jobs) - maxTrackedJobs + 1
+	for i := 0; i < toEvict && i < len(finished); i++ {
+		delete(t.jobs, finished[i].VideoID)
+	}
+}
+
+// MarkProcessing transitions a job to Processing.
+func (t *JobTracker) MarkProcessing(videoID string) {
+	t.update(videoID, func(s *JobStatus) {
+		s.State = JobProcessing
+		s.StartedAt = time.Now()
+	})
+}
+
+// MarkDone transitions a job to Done.
+func (t *JobTracker) MarkDone(videoID string) {
+	t.update(videoID, func(s *JobStatus) {
+		s.State = JobDone
+		s.FinishedAt = time.Now()
+	})
+}
+
+// MarkFailed transitions a job to Failed, recording the error.
+func (t *JobTracker) MarkFailed(videoID string, err error) {
+	t.update(videoID, func(s *JobStatus) {
+		s.State = JobFailed
+		s.Error = err.Error()
+		s.FinishedAt = time.Now()
+	})
+}
+
+// Get returns the current status for a job, and whether it is known.
+func (t *JobTracker) Get(videoID string) (JobStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.jobs[videoID]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return *s, true
+}
+
+// Subscribe returns a channel that receives every status transition as it
+// happens. The caller must invoke the returned cancel func to unsubscribe.
+func (t *JobTracker) Subscribe() (<-chan JobStatus, func()) {
+	ch := make(chan JobStatus, 16)
+
+	t.subMu.Lock()
+	t.subs[ch] = struct{}{}
+	t.subMu.Unlock()
+
+	cancel := func() {
+		t.subMu.Lock()
+		if _, ok := t.subs[ch]; ok {
+			delete(t.subs, ch)
+			close(ch)
+		}
+		t.subMu.Unlock()
+	}
+	return ch, cancel
+}
+
+func (t *JobTracker) update(videoID string, mutate func(*JobStatus)) {
+	t.mu.Lock()
+	s, ok := t.jobs[videoID]
+	if !ok {
+		s = &JobStatus{VideoID: videoID}
+		t.jobs[videoID] = s
+	}
+	mutate(s)
+	snapshot := *s
+	t.mu.Unlock()
+	t.broadcast(snapshot)
+}
+
+func (t *JobTracker) broadcast(s JobStatus) {
+	t.subMu.Lock()
+	defer t.subMu.Unlock()
+	for ch := range t.subs {
+		select {
+		case ch <- s:
+		default: // slow subscriber, drop the update rather than block the worker
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTrackPrunesOldestFinishedJobsPastMaxTrackedJobs(t *testing.T) {
+	tr := NewJobTracker()
+
+	for i := 0; i < maxTrackedJobs; i++ {
+		id := fmt.Sprintf("video_%d", i)
+		tr.Track(id)
+		tr.MarkDone(id)
+	}
+
+	if len(tr.jobs) != maxTrackedJobs {
+		t.Fatalf("expected %d tracked jobs, got %d", maxTrackedJobs, len(tr.jobs))
+	}
+
+	tr.Track("video_new")
+
+	if len(tr.jobs) != maxTrackedJobs {
+		t.Errorf("expected tracking to stay capped at %d, got %d", maxTrackedJobs, len(tr.jobs))
+	}
+	if _, ok := tr.Get("video_0"); ok {
+		t.Errorf("expected the oldest finished job to be evicted to make room")
+	}
+	if _, ok := tr.Get("video_new"); !ok {
+		t.Errorf("expected the newly tracked job to be present")
+	}
+}
+
+func TestTrackNeverEvictsJobsStillInFlight(t *testing.T) {
+	tr := NewJobTracker()
+
+	for i := 0; i < maxTrackedJobs; i++ {
+		tr.Track(fmt.Sprintf("video_%d", i))
+	}
+	tr.MarkProcessing("video_0")
+
+	tr.Track("video_new")
+
+	if _, ok := tr.Get("video_0"); !ok {
+		t.Errorf("a still-processing job should never be evicted")
+	}
+}
+
+func TestMarkFailedRecordsTheError(t *testing.T) {
+	tr := NewJobTracker()
+	tr.Track("video_1")
+
+	tr.MarkFailed("video_1", errors.New("ffmpeg exited 1"))
+
+	s, ok := tr.Get("video_1")
+	if !ok {
+		t.Fatalf("expected job to be tracked")
+	}
+	if s.State != JobFailed || s.Error != "ffmpeg exited 1" {
+		t.Errorf("got state %v error %q, want Failed / %q", s.State, s.Error, "ffmpeg exited 1")
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// MediaInfo is the subset of ffprobe's output the worker needs to validate
+// a source file before spending ffmpeg time transcoding it.
+type MediaInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+	Codec    string
+	Bitrate  int64
+}
+
+type ffprobeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+}
+
+// probeMedia runs ffprobe against path and parses its JSON output.
+func probeMedia(ctx context.Context, path string) (*MediaInfo, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(out, &probe); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{}
+	if d, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(d * float64(time.Second))
+	}
+	if br, err := strconv.ParseInt(probe.Format.BitRate, 10, 64); err == nil {
+		info.Bitrate = br
+	}
+	for _, s := range probe.Streams {
+		if s.CodecType == "video" {
+			info.Codec = s.CodecName
+			info.Width = s.Width
+			info.Height = s.Height
+			break
+		}
+	}
+	return info, nil
+}
+
+// Policy bounds what the worker will transcode. Files outside it are
+// rejected before any ffmpeg process is spawned.
+type Policy struct {
+	MaxDuration   time.Duration
+	AllowedCodecs []string // empty means any codec is allowed
+}
+
+func (p Policy) check(info *MediaInfo) error {
+	if p.MaxDuration > 0 && info.Duration > p.MaxDuration {
+		return fmt.Errorf("duration %s exceeds policy max %s", info.Duration, p.MaxDuration)
+	}
+	if len(p.AllowedCodecs) > 0 && !slices.Contains(p.AllowedCodecs, info.Codec) {
+		return fmt.Errorf("codec %q is not in the allowed list %v", info.Codec, p.AllowedCodecs)
+	}
+	return nil
+}
+
+// PolicyViolation marks an error as non-retryable: the input itself fails
+// policy, so retrying the job would just fail again.
+type PolicyViolation struct {
+	Err error
+}
+
+func (e *PolicyViolation) Error() string { return e.Err.Error() }
+func (e *PolicyViolation) Unwrap() error { return e.Err }
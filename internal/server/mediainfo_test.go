@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPolicyCheckAllowsWithinBounds(t *testing.T) {
+	p := Policy{MaxDuration: 30 * time.Minute, AllowedCodecs: []string{"h264", "hevc"}}
+	info := &MediaInfo{Duration: 10 * time.Minute, Codec: "h264"}
+
+	if err := p.check(info); err != nil {
+		t.Errorf("expected a compliant file to pass, got %v", err)
+	}
+}
+
+func TestPolicyCheckRejectsOverMaxDuration(t *testing.T) {
+	p := Policy{MaxDuration: 30 * time.Minute}
+	info := &MediaInfo{Duration: 31 * time.Minute}
+
+	if err := p.check(info); err == nil {
+		t.Errorf("expected a file over MaxDuration to be rejected")
+	}
+}
+
+func TestPolicyCheckZeroMaxDurationDisablesTheCheck(t *testing.T) {
+	p := Policy{MaxDuration: 0}
+	info := &MediaInfo{Duration: 999 * time.Hour}
+
+	if err := p.check(info); err != nil {
+		t.Errorf("MaxDuration 0 should disable the duration check, got %v", err)
+	}
+}
+
+func TestPolicyCheckRejectsDisallowedCodec(t *testing.T) {
+	p := Policy{AllowedCodecs: []string{"h264", "hevc"}}
+	info := &MediaInfo{Codec: "vp9"}
+
+	if err := p.check(info); err == nil {
+		t.Errorf("expected codec %q not in the allowed list to be rejected", info.Codec)
+	}
+}
+
+func TestPolicyCheckEmptyAllowedCodecsAllowsAny(t *testing.T) {
+	p := Policy{}
+	info := &MediaInfo{Codec: "anything"}
+
+	if err := p.check(info); err != nil {
+		t.Errorf("an empty AllowedCodecs list should allow any codec, got %v", err)
+	}
+}
+
+func TestPolicyViolationUnwraps(t *testing.T) {
+	inner := errors.New("duration exceeds policy")
+	v := &PolicyViolation{Err: inner}
+
+	if !errors.Is(v, inner) {
+		t.Errorf("expected PolicyViolation to unwrap to its inner error")
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import "context"
+
+// MemQueue is an in-memory Queue with no persistence: jobs are lost on
+// crash, same as the original chan-based WorkerPool.
+type MemQueue struct {
+	ch chan QueuedJob
+}
+
+func NewMemQueue(size int) *MemQueue {
+	return &MemQueue{ch: make(chan QueuedJob, size)}
+}
+
+func (q *MemQueue) Enqueue(ctx context.Context, job Job) (bool, error) {
+	select {
+	case q.ch <- QueuedJob{Job: job}:
+		return true, nil
+	default:
+		return false, nil // Queue Full (Leak)
+	}
+}
+
+func (q *MemQueue) Dequeue(ctx context.Context) (QueuedJob, bool) {
+	select {
+	case job, ok := <-q.ch:
+		return job, ok
+	case <-ctx.Done():
+		return QueuedJob{}, false
+	}
+}
+
+func (q *MemQueue) Requeue(ctx context.Context, job QueuedJob) error {
+	job.Attempts++
+	select {
+	case q.ch <- job:
+	default: // queue full, drop rather than block
+	}
+	return nil
+}
+
+func (q *MemQueue) Finish(ctx context.Context, job QueuedJob, jobErr error) error {
+	return nil // nothing to persist
+}
+
+func (q *MemQueue) Recover(ctx context.Context) error {
+	return nil // nothing survives a restart
+}
+
+func (q *MemQueue) Close() {
+	close(q.ch)
+}
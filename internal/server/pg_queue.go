@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// jobsSchema creates the jobs table Postgres uses to persist queue state.
+const jobsSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id          BIGSERIAL PRIMARY KEY,
+	video_id    TEXT NOT NULL,
+	state       TEXT NOT NULL DEFAULT 'queued',
+	attempts    INT NOT NULL DEFAULT 0,
+	last_error  TEXT,
+	enqueued_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	started_at  TIMESTAMPTZ,
+	finished_at TIMESTAMPTZ
+)`
+
+// PgQueue persists jobs in Postgres so they survive a crash. Dequeue polls
+// on an interval rather than blocking on LISTEN/NOTIFY, which keeps the
+// implementation simple at the job volumes this server expects.
+type PgQueue struct {
+	pool      *pgxpool.Pool
+	pollEvery time.Duration
+
+	// maxLen caps the number of queued+processing jobs, mirroring
+	// MemQueue's buffered-channel backpressure so callers relying on
+	// AddJob's queue-full signal (-q) see the same behavior regardless of
+	// queue backend. maxLen <= 0 means unbounded.
+	maxLen int
+}
+
+// NewPgQueue connects to dsn and migrates the jobs table. maxLen bounds how
+// many queued+processing jobs Enqueue will accept before reporting the
+// queue as full, same as MemQueue's buffer size; maxLen <= 0 leaves the
+// queue unbounded.
+func NewPgQueue(ctx context.Context, dsn string, maxLen int) (*PgQueue, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	if _, err := pool.Exec(ctx, jobsSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	return &PgQueue{pool: pool, pollEvery: 2 * time.Second, maxLen: maxLen}, nil
+}
+
+// Enqueue inserts job as queued, unless maxLen is set and already reached -
+// the count-then-insert isn't transactional, so concurrent callers can
+// briefly overshoot maxLen by a handful of rows, a tradeoff made for
+// simplicity at this server's expected job volumes.
+func (q *PgQueue) Enqueue(ctx context.Context, job Job) (bool, error) {
+	if q.maxLen > 0 {
+		var n int
+		row := q.pool.QueryRow(ctx,
+			`SELECT count(*) FROM jobs WHERE state IN ('queued', 'processing')`)
+		if err := row.Scan(&n); err != nil {
+			return false, err
+		}
+		if n >= q.maxLen {
+			return false, nil // Queue Full (Leak)
+		}
+	}
+
+	_, err := q.pool.Exec(ctx,
+		`INSERT INTO jobs (video_id, state) VALUES ($1, 'queued')`, job.VideoID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (q *PgQueue) Dequeue(ctx context.Context) (QueuedJob, bool) {
+	ticker := time.NewTicker(q.pollEvery)
+	defer ticker.Stop()
+
+	for {
+		job, ok, err := q.claimOne(ctx)
+		if err != nil {
+			return QueuedJob{}, false
+		}
+		if ok {
+			return job, true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return QueuedJob{}, false
+		}
+	}
+}
+
+// claimOne atomically claims the oldest queued job, if any, using
+// SKIP LOCKED so multiple workers never race for the same row.
+func (q *PgQueue) claimOne(ctx context.Context) (QueuedJob, bool, error) {
+	row := q.pool.QueryRow(ctx, `
+		UPDATE jobs SET state = 'processing', started_at = now()
+		WHERE id = (
+			SELECT id FROM jobs WHERE state = 'queued'
+			ORDER BY enqueued_at LIMIT 1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, video_id, attempts`)
+
+	var id int64
+	var videoID string
+	var attempts int
+	if err := row.Scan(&id, &videoID, &attempts); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return QueuedJob{}, false, nil
+		}
+		return QueuedJob{}, false, err
+	}
+
+	return QueuedJob{Job: Job{VideoID: videoID}, Attempts: attempts, ID: id}, true, nil
+}
+
+func (q *PgQueue) Requeue(ctx context.Context, job QueuedJob) error {
+	_, err := q.pool.Exec(ctx, `
+		UPDATE jobs SET state = 'queued', attempts = attempts + 1
+		WHERE id = $1 AND state = 'processing'`, job.ID)
+	return err
+}
+
+func (q *PgQueue) Finish(ctx context.Context, job QueuedJob, jobErr error) error {
+	if jobErr == nil {
+		_, err := q.pool.Exec(ctx, `
+			UPDATE jobs SET state = 'done', finished_at = now()
+			WHERE id = $1`, job.ID)
+		return err
+	}
+
+	_, err := q.pool.Exec(ctx, `
+		UPDATE jobs SET state = 'failed', last_error = $2, finished_at = now()
+		WHERE id = $1`, job.ID, jobErr.Error())
+	return err
+}
+
+// Recover requeues jobs left in the Processing state, e.g. by a server that
+// crashed mid-transcode, so they get picked up again.
+func (q *PgQueue) Recover(ctx context.Context) error {
+	_, err := q.pool.Exec(ctx, `UPDATE jobs SET state = 'queued' WHERE state = 'processing'`)
+	return err
+}
+
+func (q *PgQueue) Close() {
+	q.pool.Close()
+}
@@ -0,0 +1,42 @@
+package server
+
+import "context"
+
+// QueuedJob couples a Job with how many attempts have already been made
+// processing it, so WorkerPool's retry/backoff logic can act on it.
+type QueuedJob struct {
+	Job
+	Attempts int
+
+	// ID identifies the backing row for queues that persist jobs, e.g.
+	// PgQueue. It is the key Requeue/Finish act on, since VideoID is not
+	// guaranteed unique (two uploads can share a filename). Unused and
+	// left zero by MemQueue.
+	ID int64
+}
+
+// Queue is the pluggable backing store for WorkerPool's job queue. MemQueue
+// keeps jobs in memory, matching the original chan-based behavior; PgQueue
+// persists them to Postgres so ffmpeg work survives a crash.
+type Queue interface {
+	// Enqueue adds a job, returning false if the queue is full (leaky bucket).
+	Enqueue(ctx context.Context, job Job) (bool, error)
+
+	// Dequeue blocks until a job is available or ctx is canceled, and
+	// reports false once the queue has been closed and drained.
+	Dequeue(ctx context.Context) (QueuedJob, bool)
+
+	// Requeue puts a job back for another attempt after a failure.
+	Requeue(ctx context.Context, job QueuedJob) error
+
+	// Finish marks a job Done (jobErr == nil) or permanently Failed.
+	Finish(ctx context.Context, job QueuedJob, jobErr error) error
+
+	// Recover requeues any jobs left in the Processing state, e.g. after a
+	// crash. It is called once, on startup.
+	Recover(ctx context.Context) error
+
+	// Close releases the queue's resources. Workers exit once it has
+	// drained.
+	Close()
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// idPattern is the exact shape Init hands back: 16 random bytes, hex
+// encoded. Every exported method below that takes an id gets it from a
+// client-controlled HTTP header or RPC field and splices it into
+// filepath.Join, so anything
+// not matching this pattern (e.g. "../../etc/passwd") must be rejected
+// before it reaches a path.
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// ByteRange is an inclusive-start, exclusive-end span of bytes already
+// received for an upload.
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// UploadSession tracks a resumable upload in progress. It is persisted to
+// disk so clients can resume after a network failure or a server restart.
+type UploadSession struct {
+	ID        string      `json:"id"`
+	Filename  string      `json:"filename"`
+	TotalSize int64       `json:"total_size"`
+	SHA256    string      `json:"sha256,omitempty"`
+	Received  []ByteRange `json:"received"`
+}
+
+func (s *UploadSession) markReceived(start, end int64) {
+	s.Received = append(s.Received, ByteRange{Start: start, End: end})
+	sort.Slice(s.Received, func(i, j int) bool { return s.Received[i].Start < s.Received[j].Start })
+
+	merged := s.Received[:0]
+	for _, r := range s.Received {
+		if len(merged) > 0 && r.Start <= merged[len(merged)-1].End {
+			if r.End > merged[len(merged)-1].End {
+				merged[len(merged)-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	s.Received = merged
+}
+
+func (s *UploadSession) isComplete() bool {
+	return len(s.Received) == 1 && s.Received[0].Start == 0 && s.Received[0].End >= s.TotalSize
+}
+
+// ResumableManager stores in-progress uploads on local disk: metadata
+// (received byte ranges) as JSON, and the data itself in a pre-allocated
+// file written via WriteAt so chunks can arrive out of order and be retried
+// idempotently.
+type ResumableManager struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewResumableManager(dir string) (*ResumableManager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create resumable upload dir: %w", err)
+	}
+	return &ResumableManager{dir: dir}, nil
+}
+
+// Init starts a new upload session and returns its ID.
+func (m *ResumableManager) Init(filename string, totalSize int64, sha256Hex string) (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("generate upload id: %w", err)
+	}
+	id := hex.EncodeToString(idBytes)
+
+	f, err := os.Create(m.dataPath(id))
+	if err != nil {
+		return "", fmt.Errorf("allocate upload file: %w", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(totalSize); err != nil {
+		return "", fmt.Errorf("allocate upload file: %w", err)
+	}
+
+	sess := &UploadSession{ID: id, Filename: filename, TotalSize: totalSize, SHA256: sha256Hex}
+	if err := m.save(sess); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// WriteChunk writes data at offset. Writing the same offset/data again is a
+// no-op from the client's point of view: the byte range is simply re-marked
+// as received.
+func (m *ResumableManager) WriteChunk(id string, offset int64, data []byte) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("invalid upload id %q", id)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sess, err := m.load(id)
+	if err != nil {
+		return err
+	}
+
+	if offset < 0 || offset+int64(len(data)) > sess.TotalSize {
+		return fmt.Errorf("chunk [%d, %d) is out of bounds for upload %s of size %d", offset, offset+int64(len(data)), id, sess.TotalSize)
+	}
+
+	f, err := os.OpenFile(m.dataPath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open upload file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteAt(data, offset); err != nil {
+		return fmt.Errorf("write chunk: %w", err)
+	}
+
+	sess.markReceived(offset, offset+int64(len(data)))
+	return m.save(sess)
+}
+
+// Status returns the current session, for resume queries.
+func (m *ResumableManager) Status(id string) (*UploadSession, error) {
+	if !idPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid upload id %q", id)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.load(id)
+}
+
+// Complete validates that every byte has arrived and that the checksum
+// matches, then returns a reader over the assembled file positioned at the
+// start. The caller is responsible for closing it and calling Cleanup once
+// it has been stored.
+func (m *ResumableManager) Complete(ctx context.Context, id string) (*UploadSession, *os.File, error) {
+	if !idPattern.MatchString(id) {
+		return nil, nil, fmt.Errorf("invalid upload id %q", id)
+	}
+
+	m.mu.Lock()
+	sess, err := m.load(id)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !sess.isComplete() {
+		return nil, nil, fmt.Errorf("upload %s is incomplete: missing byte ranges", id)
+	}
+
+	f, err := os.Open(m.dataPath(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("open assembled upload: %w", err)
+	}
+
+	if sess.SHA256 != "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("checksum upload: %w", err)
+		}
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != sess.SHA256 {
+			f.Close()
+			return nil, nil, fmt.Errorf("checksum mismatch: expected %s, got %s", sess.SHA256, sum)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("rewind assembled upload: %w", err)
+		}
+	}
+
+	return sess, f, nil
+}
+
+// Cleanup removes a session's staged data and metadata once it has been
+// stored or abandoned.
+func (m *ResumableManager) Cleanup(id string) {
+	if !idPattern.MatchString(id) {
+		return
+	}
+	os.Remove(m.dataPath(id))
+	os.Remove(m.metaPath(id))
+}
+
+func (m *ResumableManager) dataPath(id string) string { return filepath.Join(m.dir, id+".part") }
+func (m *ResumableManager) metaPath(id string) string { return filepath.Join(m.dir, id+".json") }
+
+func (m *ResumableManager) save(sess *UploadSession) error {
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("marshal upload session: %w", err)
+	}
+	if err := os.WriteFile(m.metaPath(sess.ID), b, 0644); err != nil {
+		return fmt.Errorf("persist upload session: %w", err)
+	}
+	return nil
+}
+
+func (m *ResumableManager) load(id string) (*UploadSession, error) {
+	b, err := os.ReadFile(m.metaPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("unknown upload %s: %w", id, err)
+	}
+	var sess UploadSession
+	if err := json.Unmarshal(b, &sess); err != nil {
+		return nil, fmt.Errorf("load upload session: %w", err)
+	}
+	return &sess, nil
+}
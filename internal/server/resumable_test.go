@@ -0,0 +1,95 @@
+package server
+
+import "testing"
+
+func TestWriteChunkRejectsPathTraversalID(t *testing.T) {
+	m, err := NewResumableManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewResumableManager: %v", err)
+	}
+
+	if err := m.WriteChunk("../../etc/passwd", 0, []byte("x")); err == nil {
+		t.Errorf("expected a non-hex upload id to be rejected")
+	}
+}
+
+func TestWriteChunkRejectsOffsetPastTotalSize(t *testing.T) {
+	m, err := NewResumableManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewResumableManager: %v", err)
+	}
+
+	id, err := m.Init("clip.mp4", 10, "")
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	if err := m.WriteChunk(id, 1<<30, []byte("x")); err == nil {
+		t.Errorf("expected a chunk past TotalSize to be rejected")
+	}
+}
+
+func TestMarkReceivedMergesOverlappingRanges(t *testing.T) {
+	sess := &UploadSession{TotalSize: 100}
+
+	sess.markReceived(0, 10)
+	sess.markReceived(5, 20)
+
+	if len(sess.Received) != 1 {
+		t.Fatalf("expected overlapping ranges to merge into one, got %v", sess.Received)
+	}
+	if sess.Received[0] != (ByteRange{Start: 0, End: 20}) {
+		t.Errorf("expected merged range {0 20}, got %v", sess.Received[0])
+	}
+}
+
+func TestMarkReceivedMergesAdjacentRanges(t *testing.T) {
+	sess := &UploadSession{TotalSize: 100}
+
+	sess.markReceived(10, 20)
+	sess.markReceived(0, 10)
+
+	if len(sess.Received) != 1 {
+		t.Fatalf("expected adjacent ranges (end == next start) to merge, got %v", sess.Received)
+	}
+	if sess.Received[0] != (ByteRange{Start: 0, End: 20}) {
+		t.Errorf("expected merged range {0 20}, got %v", sess.Received[0])
+	}
+}
+
+func TestMarkReceivedKeepsDisjointRangesSeparate(t *testing.T) {
+	sess := &UploadSession{TotalSize: 100}
+
+	sess.markReceived(0, 10)
+	sess.markReceived(20, 30)
+
+	if len(sess.Received) != 2 {
+		t.Fatalf("expected two disjoint ranges, got %v", sess.Received)
+	}
+}
+
+func TestMarkReceivedIsIdempotentOnRetry(t *testing.T) {
+	sess := &UploadSession{TotalSize: 100}
+
+	sess.markReceived(0, 10)
+	sess.markReceived(0, 10)
+
+	if len(sess.Received) != 1 || sess.Received[0] != (ByteRange{Start: 0, End: 10}) {
+		t.Errorf("resending the same chunk should be a no-op, got %v", sess.Received)
+	}
+}
+
+func TestIsCompleteRequiresOneRangeCoveringTheWholeFile(t *testing.T) {
+	sess := &UploadSession{TotalSize: 30}
+
+	sess.markReceived(0, 10)
+	sess.markReceived(20, 30)
+	if sess.isComplete() {
+		t.Errorf("should be incomplete while a gap remains")
+	}
+
+	sess.markReceived(10, 20)
+	if !sess.isComplete() {
+		t.Errorf("should be complete once every byte is covered by one range")
+	}
+}
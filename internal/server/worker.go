@@ -1,27 +1,76 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"os/exec"
+	"os"
+	"runtime"
 	"sync"
+	"time"
+
+	"github.com/Perdit-io/media-upload/internal/storage"
 )
 
+// Job identifies a source video to transcode, keyed by VideoID. The source
+// and its derived assets live in the WorkerPool's storage.Backend under
+// that key.
 type Job struct {
-	Filename string
-	VideoID  string
+	VideoID string
+}
+
+// SourceKey returns the storage key the original upload is saved and read
+// back under. It lives under a videoID/ prefix rather than at the flat key
+// videoID itself, so it can't collide with generateHLSLadder's use of
+// videoID as a directory prefix for the rungs and master playlist it writes
+// alongside it.
+func SourceKey(videoID string) string {
+	return videoID + "/source.mp4"
 }
 
 type WorkerPool struct {
-	JobQueue    chan Job // Leaky Bucket
+	Queue       Queue
 	WorkerCount int
-	wg          sync.WaitGroup
+	MaxAttempts int
+	Policy      Policy
+	Tracker     *JobTracker
+	Storage     storage.Backend
+
+	// ffmpegSem bounds the number of simultaneous ffmpeg processes across
+	// all workers, separate from WorkerCount: a single job now spawns one
+	// ffmpeg process per HLS rung.
+	ffmpegSem chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func NewWorkerPool(c int, q int) *WorkerPool {
+// NewWorkerPool builds a pool. ffmpegConcurrency <= 0 defaults to
+// runtime.NumCPU().
+func NewWorkerPool(c int, queue Queue, backend storage.Backend, maxAttempts int, policy Policy, ffmpegConcurrency int) *WorkerPool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := queue.Recover(ctx); err != nil {
+		slog.Error("Failed to recover in-flight jobs", "error", err)
+	}
+
+	if ffmpegConcurrency <= 0 {
+		ffmpegConcurrency = runtime.NumCPU()
+	}
+
 	return &WorkerPool{
-		JobQueue:    make(chan Job, q),
+		Queue:       queue,
 		WorkerCount: c,
+		MaxAttempts: maxAttempts,
+		Policy:      policy,
+		Tracker:     NewJobTracker(),
+		Storage:     backend,
+		ffmpegSem:   make(chan struct{}, ffmpegConcurrency),
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 }
 
@@ -34,50 +83,117 @@ func (wp *WorkerPool) Start() {
 }
 
 func (wp *WorkerPool) AddJob(job Job) bool {
-	select {
-	case wp.JobQueue <- job:
-		return true // Success
-	default:
-		return false // Queue Full (Leak)
+	added, err := wp.Queue.Enqueue(wp.ctx, job)
+	if err != nil {
+		slog.Error("Failed to enqueue job", "video_id", job.VideoID, "error", err)
+		return false
+	}
+	if added {
+		wp.Tracker.Track(job.VideoID)
 	}
+	return added
 }
 
-// Stop waits for workers to finish
+// Stop signals workers to exit once the queue has drained and waits for
+// them. Queue.Close happens before cancel: for MemQueue, Dequeue's select
+// races ctx.Done() against a pending value on the channel, so canceling
+// first can silently drop a job that was still sitting in the queue.
+// Closing (and letting Dequeue drain) first guarantees every already
+// enqueued job gets processed before workers exit.
 func (wp *WorkerPool) Stop() {
-	close(wp.JobQueue)
+	wp.Queue.Close()
 	wp.wg.Wait()
+	wp.cancel()
 }
 
 func (wp *WorkerPool) worker(id int) {
 	defer wp.wg.Done()
 
-	for job := range wp.JobQueue {
-		slog.Info("Processing Video", "worker", id, "file", job.Filename)
-
-		inputFile := job.Filename
-		outputGif := fmt.Sprintf("uploads/%s_preview.gif", job.VideoID)
-		outputJpg := fmt.Sprintf("uploads/%s_thumbnail.jpg", job.VideoID)
+	for {
+		job, ok := wp.Queue.Dequeue(wp.ctx)
+		if !ok {
+			return
+		}
 
-		// Generates a 5-second GIF, scaled to 320px width
-		cmdGif := exec.Command("ffmpeg", "-y", "-i", inputFile, "-t", "5", "-vf", "fps=10,scale=320:-1", outputGif)
-		cmdGif.Stdout = nil
-		cmdGif.Stderr = nil
+		slog.Info("Processing Video", "worker", id, "video", job.VideoID, "attempt", job.Attempts+1)
+		wp.Tracker.MarkProcessing(job.VideoID)
 
-		if err := cmdGif.Run(); err != nil {
-			slog.Error("FFmpeg GIF generation failed", "worker", id, "error", err)
+		if err := wp.processJob(job.Job); err != nil {
+			wp.handleFailure(id, job, err)
 			continue
 		}
 
-		// Generate JPG (Static Thumbnail) snapshot at the 1-second mark
-		cmdJpg := exec.Command("ffmpeg", "-y", "-i", inputFile, "-ss", "00:00:01", "-vframes", "1", "-vf", "scale=320:-1", outputJpg)
-		cmdJpg.Stdout = nil
-		cmdJpg.Stderr = nil
+		slog.Info("Video Processed", "worker", id, "video", job.VideoID)
+		wp.Tracker.MarkDone(job.VideoID)
+		if err := wp.Queue.Finish(wp.ctx, job, nil); err != nil {
+			slog.Error("Failed to record job completion", "video_id", job.VideoID, "error", err)
+		}
+	}
+}
 
-		if err := cmdJpg.Run(); err != nil {
-			slog.Error("FFmpeg JPG generation failed", "worker", id, "error", err)
-			continue
+// handleFailure retries a failed job with exponential backoff, up to
+// MaxAttempts, before marking it permanently Failed.
+func (wp *WorkerPool) handleFailure(id int, job QueuedJob, err error) {
+	slog.Error("Video processing failed", "worker", id, "video", job.VideoID, "attempt", job.Attempts+1, "error", err)
+
+	if isTerminalFailure(err, job.Attempts+1, wp.MaxAttempts) {
+		wp.Tracker.MarkFailed(job.VideoID, err)
+		if ferr := wp.Queue.Finish(wp.ctx, job, err); ferr != nil {
+			slog.Error("Failed to record job failure", "video_id", job.VideoID, "error", ferr)
 		}
+		return
+	}
+
+	backoff := time.Duration(1<<job.Attempts) * time.Second
+	slog.Warn("Retrying video after backoff", "video_id", job.VideoID, "backoff", backoff)
+	time.Sleep(backoff)
 
-		slog.Info("Video Processed", "worker", id, "gif", outputGif, "jpg", outputJpg)
+	if rerr := wp.Queue.Requeue(wp.ctx, job); rerr != nil {
+		slog.Error("Failed to requeue job", "video_id", job.VideoID, "error", rerr)
 	}
 }
+
+// isTerminalFailure reports whether a failed attempt should end the job
+// instead of being retried: a PolicyViolation is never retryable since the
+// input itself is rejected, and any other error stops being retried once
+// attemptsMade reaches maxAttempts.
+func isTerminalFailure(err error, attemptsMade, maxAttempts int) bool {
+	var policyErr *PolicyViolation
+	return errors.As(err, &policyErr) || attemptsMade >= maxAttempts
+}
+
+// processJob stages the source video locally (ffmpeg and ffprobe both need
+// a seekable file), rejects it if it falls outside Policy, and otherwise
+// transcodes it into an adaptive HLS ladder.
+func (wp *WorkerPool) processJob(job Job) error {
+	ctx := wp.ctx
+
+	src, err := wp.Storage.Open(ctx, SourceKey(job.VideoID))
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer src.Close()
+
+	input, err := os.CreateTemp("", "upload-*.mp4")
+	if err != nil {
+		return fmt.Errorf("stage source: %w", err)
+	}
+	defer os.Remove(input.Name())
+
+	if _, err := io.Copy(input, src); err != nil {
+		input.Close()
+		return fmt.Errorf("stage source: %w", err)
+	}
+	input.Close()
+
+	info, err := probeMedia(ctx, input.Name())
+	if err != nil {
+		return fmt.Errorf("probe source: %w", err)
+	}
+
+	if err := wp.Policy.check(info); err != nil {
+		return &PolicyViolation{Err: err}
+	}
+
+	return wp.generateHLSLadder(ctx, input.Name(), job.VideoID, info)
+}
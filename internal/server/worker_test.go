@@ -0,0 +1,33 @@
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsTerminalFailurePolicyViolation(t *testing.T) {
+	err := &PolicyViolation{Err: errors.New("duration exceeds policy")}
+
+	if !isTerminalFailure(err, 1, 5) {
+		t.Errorf("a PolicyViolation should always be terminal, even on the first attempt")
+	}
+}
+
+func TestIsTerminalFailureRetriesBelowMaxAttempts(t *testing.T) {
+	err := errors.New("ffmpeg exited 1")
+
+	if isTerminalFailure(err, 1, 3) {
+		t.Errorf("attempt 1 of 3 should be retried, not terminal")
+	}
+	if isTerminalFailure(err, 2, 3) {
+		t.Errorf("attempt 2 of 3 should be retried, not terminal")
+	}
+}
+
+func TestIsTerminalFailureStopsAtMaxAttempts(t *testing.T) {
+	err := errors.New("ffmpeg exited 1")
+
+	if !isTerminalFailure(err, 3, 3) {
+		t.Errorf("attempt 3 of 3 should be terminal")
+	}
+}
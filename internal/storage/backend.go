@@ -0,0 +1,49 @@
+// Package storage abstracts where uploaded videos and their derived HLS
+// assets (rung segments, variant playlists, the master playlist) are
+// persisted, so the gRPC server, the web upload handler and the worker
+// don't need to know whether they're writing to local disk or a remote
+// object store.
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Backend reads and writes objects by key.
+type Backend interface {
+	// Save writes the contents of r under key, overwriting any existing
+	// object at that key.
+	Save(ctx context.Context, key string, r io.Reader) error
+
+	// Open returns a reader for the object at key. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object at key. It is not an error to delete a key
+	// that doesn't exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// Redirector is implemented by backends that serve objects via a URL
+// instead of streaming bytes through this process, e.g. S3 presigned URLs.
+type Redirector interface {
+	Backend
+
+	// URL returns a URL the browser can fetch key from directly, valid for
+	// expires.
+	URL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Lister is implemented by backends that can enumerate the keys stored
+// under a prefix, e.g. so a caller can list uploaded videos without
+// keeping a side index of what's been saved.
+type Lister interface {
+	Backend
+
+	// List returns the immediate children of prefix, as if prefix were a
+	// directory: one entry per direct child key or "sub-directory", with
+	// no trailing slash and not recursing past it. prefix "" lists the
+	// backend's top level.
+	List(ctx context.Context, prefix string) ([]string, error)
+}